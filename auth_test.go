@@ -0,0 +1,23 @@
+package fitz
+
+import "testing"
+
+func TestDocument_NeedsPassword(t *testing.T) {
+	doc := newTestDocument(t)
+
+	if doc.NeedsPassword() {
+		t.Fatal("unexpected NeedsPassword on an unencrypted document")
+	}
+}
+
+func TestNewFromMemoryWithPassword_NoPasswordNeeded(t *testing.T) {
+	doc, err := NewFromMemoryWithPassword([]byte(testPDF), "irrelevant")
+	if err != nil {
+		t.Fatalf("NewFromMemoryWithPassword: %v", err)
+	}
+	defer doc.Close()
+
+	if doc.NumPage() != 1 {
+		t.Errorf("NumPage() = %d, want 1", doc.NumPage())
+	}
+}
@@ -1,20 +1,21 @@
-// Package fitz provides wrapper for the [MuPDF](http://mupdf.com/) that can extract images from PDF, EPUB and XPS documents.
+// Package fitz provides wrapper for the [MuPDF](http://mupdf.com/) that can extract images from PDF, EPUB, XPS, CBZ, JPEG, PNG and TIFF documents.
 package fitz
 
 /*
 #include <mupdf/fitz.h>
 #include <stdlib.h>
+#include "fitz_shim.h"
 
 #cgo CFLAGS: -Iinclude
 
-#cgo linux,amd64 LDFLAGS: -L${SRCDIR}/libs -lmupdf_linux_amd64 -lmupdfthird_linux_amd64 -lm
-#cgo linux,!android,arm LDFLAGS: -L${SRCDIR}/libs -lmupdf_linux_arm -lmupdfthird_linux_arm -lm
-#cgo linux,!android,arm64 LDFLAGS: -L${SRCDIR}/libs -lmupdf_linux_arm64 -lmupdfthird_linux_arm64 -lm
+#cgo linux,amd64 LDFLAGS: -L${SRCDIR}/libs -lmupdf_linux_amd64 -lmupdfthird_linux_amd64 -lm -lpthread
+#cgo linux,!android,arm LDFLAGS: -L${SRCDIR}/libs -lmupdf_linux_arm -lmupdfthird_linux_arm -lm -lpthread
+#cgo linux,!android,arm64 LDFLAGS: -L${SRCDIR}/libs -lmupdf_linux_arm64 -lmupdfthird_linux_arm64 -lm -lpthread
 #cgo android,arm LDFLAGS: -L${SRCDIR}/libs -lmupdf_android_arm -lmupdfthird_android_arm -lm
 #cgo android,arm64 LDFLAGS: -L${SRCDIR}/libs -lmupdf_android_arm64 -lmupdfthird_android_arm64 -lm
 #cgo windows,386 LDFLAGS: -L${SRCDIR}/libs -lmupdf_windows_386 -lmupdfthird_windows_386 -lm
 #cgo windows,amd64 LDFLAGS: -L${SRCDIR}/libs -lmupdf_windows_amd64 -lmupdfthird_windows_amd64 -lm
-#cgo darwin,amd64 LDFLAGS: -L${SRCDIR}/libs -lmupdf_darwin_amd64 -lmupdfthird_darwin_amd64 -lm
+#cgo darwin,amd64 LDFLAGS: -L${SRCDIR}/libs -lmupdf_darwin_amd64 -lmupdfthird_darwin_amd64 -lm -lpthread
 
 const char *fz_version = FZ_VERSION;
 */
@@ -27,6 +28,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 	"unsafe"
 )
 
@@ -42,10 +44,64 @@ var (
 	ErrNeedsPassword = errors.New("fitz: document needs password")
 )
 
+// ShimError wraps a MuPDF-side error message captured by the C shim in
+// fitz_shim.c: each wrapped call runs inside fz_try/fz_catch so that a
+// malformed document or internal MuPDF fault (OOM, stream decode
+// failure, ...) surfaces as a Go error instead of aborting the process
+// via fz_throw's longjmp.
+type ShimError struct {
+	Code    int
+	Message string
+}
+
+func (e *ShimError) Error() string {
+	return "fitz: " + e.Message
+}
+
+// checkShim converts a fitz_shim_result returned by a shim call into a Go
+// error, freeing the C-allocated message if the call failed.
+func checkShim(r C.fitz_shim_result) error {
+	if r.code == 0 {
+		return nil
+	}
+
+	err := &ShimError{Code: int(r.code), Message: C.GoString(r.message)}
+	C.free(unsafe.Pointer(r.message))
+
+	return err
+}
+
 // Document represents fitz document
 type Document struct {
-	ctx *C.struct_fz_context_s
-	doc *C.struct_fz_document_s
+	ctx   *C.struct_fz_context_s
+	doc   *C.struct_fz_document_s
+	locks *C.fitz_shim_locks
+
+	// poolOnce and ctxPool back the cloned-context pool used by CachedPage's
+	// Render, so concurrent renders don't share a single fz_context. ctxWG
+	// tracks outstanding borrowContext calls so Close can wait for them to
+	// finish before it drops the pool's contexts.
+	poolOnce sync.Once
+	ctxPool  chan *C.struct_fz_context_s
+	ctxWG    sync.WaitGroup
+}
+
+// newContext creates an fz_context with an fz_locks_context installed, so
+// contexts cloned from it via fz_clone_context (used by CachedPage's Render for
+// concurrent rendering) can be driven safely from multiple goroutines:
+// clones still share the parent's resource store and glyph cache, and
+// MuPDF requires real lock callbacks before that sharing is exercised
+// concurrently.
+func newContext() (ctx *C.struct_fz_context_s, locks *C.fitz_shim_locks) {
+	locks = C.fitz_shim_new_locks()
+
+	ctx = (*C.struct_fz_context_s)(unsafe.Pointer(C.fitz_shim_new_context(locks, C.FZ_STORE_UNLIMITED, C.fz_version)))
+	if ctx == nil {
+		C.fitz_shim_free_locks(locks)
+		return nil, nil
+	}
+
+	return ctx, locks
 }
 
 // New returns new fitz document.
@@ -62,7 +118,7 @@ func New(filename string) (f *Document, err error) {
 		return
 	}
 
-	f.ctx = (*C.struct_fz_context_s)(unsafe.Pointer(C.fz_new_context_imp(nil, nil, C.FZ_STORE_UNLIMITED, C.fz_version)))
+	f.ctx, f.locks = newContext()
 	if f.ctx == nil {
 		err = ErrCreateContext
 		return
@@ -73,25 +129,51 @@ func New(filename string) (f *Document, err error) {
 	cfilename := C.CString(filename)
 	defer C.free(unsafe.Pointer(cfilename))
 
-	f.doc = C.fz_open_document(f.ctx, cfilename)
+	res := C.fitz_shim_open_document(f.ctx, cfilename, &f.doc)
+	if err = checkShim(res); err != nil {
+		return
+	}
+
 	if f.doc == nil {
 		err = ErrOpenDocument
 	}
 
-	ret := C.fz_needs_password(f.ctx, f.doc)
-	v := bool(int(ret) != 0)
-	if v {
-		err = ErrNeedsPassword
+	return
+}
+
+// NewWithPassword returns new fitz document, unlocking it with the given
+// password if it is encrypted.
+func NewWithPassword(filename, password string) (f *Document, err error) {
+	f, err = New(filename)
+	if err != nil {
+		return
+	}
+
+	if f.NeedsPassword() {
+		err = f.Authenticate(password)
 	}
 
 	return
 }
 
-// NewFromMemory returns new fitz document from byte slice.
+// NewFromMemory returns new fitz document from byte slice. The document's
+// format (PDF, EPUB, XPS, CBZ, JPEG, PNG or TIFF) is detected automatically
+// from its leading bytes; use NewFromMemoryWithMimeType if the format is
+// already known or sniffing guesses wrong.
 func NewFromMemory(b []byte) (f *Document, err error) {
+	return NewFromMemoryWithMimeType(b, sniffMimeType(b))
+}
+
+// NewFromMemoryWithMimeType returns new fitz document from byte slice,
+// opening it with the given MIME/magic string instead of sniffing it from
+// the data. mimetype is passed straight through to
+// fz_open_document_with_stream, so any value MuPDF's document handler
+// registry recognizes (e.g. "application/pdf", "image/jpeg",
+// "application/epub+zip", "application/x-cbz") is accepted.
+func NewFromMemoryWithMimeType(b []byte, mimetype string) (f *Document, err error) {
 	f = &Document{}
 
-	f.ctx = (*C.struct_fz_context_s)(unsafe.Pointer(C.fz_new_context_imp(nil, nil, C.FZ_STORE_UNLIMITED, C.fz_version)))
+	f.ctx, f.locks = newContext()
 	if f.ctx == nil {
 		err = ErrCreateContext
 		return
@@ -107,18 +189,31 @@ func NewFromMemory(b []byte) (f *Document, err error) {
 		return
 	}
 
-	cmagic := C.CString("application/pdf")
+	cmagic := C.CString(mimetype)
 	defer C.free(unsafe.Pointer(cmagic))
 
-	f.doc = C.fz_open_document_with_stream(f.ctx, cmagic, stream)
+	res := C.fitz_shim_open_document_with_stream(f.ctx, cmagic, stream, &f.doc)
+	if err = checkShim(res); err != nil {
+		return
+	}
+
 	if f.doc == nil {
 		err = ErrOpenDocument
 	}
 
-	ret := C.fz_needs_password(f.ctx, f.doc)
-	v := bool(int(ret) != 0)
-	if v {
-		err = ErrNeedsPassword
+	return
+}
+
+// NewFromMemoryWithPassword returns new fitz document from byte slice,
+// unlocking it with the given password if it is encrypted.
+func NewFromMemoryWithPassword(b []byte, password string) (f *Document, err error) {
+	f, err = NewFromMemory(b)
+	if err != nil {
+		return
+	}
+
+	if f.NeedsPassword() {
+		err = f.Authenticate(password)
 	}
 
 	return
@@ -137,61 +232,58 @@ func NewFromReader(r io.Reader) (f *Document, err error) {
 	return
 }
 
-// NumPage returns total number of pages in document
-func (f *Document) NumPage() int {
-	return int(C.fz_count_pages(f.ctx, f.doc))
+// NeedsPassword reports whether the document is encrypted and requires a
+// call to Authenticate before it can be rendered.
+func (f *Document) NeedsPassword() bool {
+	ret := C.fz_needs_password(f.ctx, f.doc)
+	return int(ret) != 0
 }
 
-// Image returns image for given page number.
-func (f *Document) Image(pageNumber int) (image.Image, error) {
-	if pageNumber >= f.NumPage() {
-		return nil, ErrPageMissing
-	}
-
-	page := C.fz_load_page(f.ctx, f.doc, C.int(pageNumber))
-	defer C.fz_drop_page(f.ctx, page)
-
-	var bounds C.fz_rect
-	C.fz_bound_page(f.ctx, page, &bounds)
-
-	var ctm C.fz_matrix
-	C.fz_scale(&ctm, C.float(300.0/72), C.float(300.0/72))
-
-	var bbox C.fz_irect
-	C.fz_transform_rect(&bounds, &ctm)
-	C.fz_round_rect(&bbox, &bounds)
-
-	pixmap := C.fz_new_pixmap_with_bbox(f.ctx, C.fz_device_rgb(f.ctx), &bbox, nil, 1)
-	if pixmap == nil {
-		return nil, ErrCreatePixmap
-	}
-
-	C.fz_clear_pixmap_with_value(f.ctx, pixmap, C.int(0xff))
-	defer C.fz_drop_pixmap(f.ctx, pixmap)
-
-	device := C.fz_new_draw_device(f.ctx, &ctm, pixmap)
-	defer C.fz_drop_device(f.ctx, device)
-
-	draw_matrix := C.fz_identity
-	C.fz_run_page(f.ctx, page, device, &draw_matrix, nil)
+// Authenticate unlocks an encrypted document with the given password. It
+// wraps fz_authenticate_password and returns ErrNeedsPassword if the
+// password was rejected.
+func (f *Document) Authenticate(password string) error {
+	cpassword := C.CString(password)
+	defer C.free(unsafe.Pointer(cpassword))
 
-	pixels := C.fz_pixmap_samples(f.ctx, pixmap)
-	if pixels == nil {
-		return nil, ErrPixmapSamples
+	ret := C.fz_authenticate_password(f.ctx, f.doc, cpassword)
+	if int(ret) == 0 {
+		return ErrNeedsPassword
 	}
 
-	rect := image.Rect(int(bbox.x0), int(bbox.y0), int(bbox.x1), int(bbox.y1))
-	bytes := C.GoBytes(unsafe.Pointer(pixels), C.int(4*bbox.x1*bbox.y1))
-	img := &image.RGBA{bytes, 4 * rect.Max.X, rect}
+	return nil
+}
 
-	C.fz_close_device(f.ctx, device)
+// NumPage returns total number of pages in document
+func (f *Document) NumPage() int {
+	return int(C.fz_count_pages(f.ctx, f.doc))
+}
 
-	return img, nil
+// Image returns image for given page number, rendered at the default
+// options (300 DPI, RGB, opaque, no rotation).
+func (f *Document) Image(pageNumber int) (image.Image, error) {
+	return f.ImagePage(pageNumber, DefaultImageOptions())
 }
 
-// Close closes the underlying fitz document.
+// Close closes the underlying fitz document. It waits for any in-flight
+// CachedPage.Render or Thumbnail calls to release their borrowed context
+// before closing the context pool, so a concurrent render can never send to
+// (or receive from) a pool that Close has already torn down.
 func (f *Document) Close() error {
+	if f.ctxPool != nil {
+		f.ctxWG.Wait()
+		close(f.ctxPool)
+		for ctx := range f.ctxPool {
+			C.fz_drop_context(ctx)
+		}
+	}
+
 	C.fz_drop_document(f.ctx, f.doc)
 	C.fz_drop_context(f.ctx)
+
+	if f.locks != nil {
+		C.fitz_shim_free_locks(f.locks)
+	}
+
 	return nil
 }
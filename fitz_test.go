@@ -0,0 +1,33 @@
+package fitz
+
+import "testing"
+
+// testPDF is a minimal single-page PDF: one Helvetica-labelled text run and
+// no xref table. MuPDF repairs documents like this by scanning for "obj"
+// keywords, so it's enough to exercise the wrapper without a real
+// testdata asset.
+const testPDF = `%PDF-1.4
+1 0 obj<</Type/Catalog/Pages 2 0 R>>endobj
+2 0 obj<</Type/Pages/Kids[3 0 R]/Count 1>>endobj
+3 0 obj<</Type/Page/Parent 2 0 R/MediaBox[0 0 200 200]/Resources<</Font<</F1 5 0 R>>>>/Contents 4 0 R>>endobj
+4 0 obj<</Length 36>>stream
+BT /F1 24 Tf 10 100 Td (Hello) Tj ET
+endstream
+endobj
+5 0 obj<</Type/Font/Subtype/Type1/BaseFont/Helvetica>>endobj
+trailer<</Size 6/Root 1 0 R>>
+%%EOF
+`
+
+// newTestDocument opens testPDF and registers t.Cleanup to close it.
+func newTestDocument(t *testing.T) *Document {
+	t.Helper()
+
+	doc, err := NewFromMemory([]byte(testPDF))
+	if err != nil {
+		t.Fatalf("NewFromMemory: %v", err)
+	}
+	t.Cleanup(func() { doc.Close() })
+
+	return doc
+}
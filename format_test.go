@@ -0,0 +1,88 @@
+package fitz
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// tinyImage returns a trivial 4x4 white image, just enough raster content
+// to produce valid encoded PNG/JPEG bytes for the format-detection tests
+// below.
+func tinyImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+	return img
+}
+
+func TestNewFromMemory_PNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, tinyImage()); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+
+	doc, err := NewFromMemory(buf.Bytes())
+	if err != nil {
+		t.Fatalf("NewFromMemory: %v", err)
+	}
+	defer doc.Close()
+
+	if doc.NumPage() != 1 {
+		t.Errorf("NumPage() = %d, want 1", doc.NumPage())
+	}
+}
+
+func TestNewFromMemory_JPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, tinyImage(), nil); err != nil {
+		t.Fatalf("jpeg.Encode: %v", err)
+	}
+
+	doc, err := NewFromMemory(buf.Bytes())
+	if err != nil {
+		t.Fatalf("NewFromMemory: %v", err)
+	}
+	defer doc.Close()
+
+	if doc.NumPage() != 1 {
+		t.Errorf("NumPage() = %d, want 1", doc.NumPage())
+	}
+}
+
+func TestNewFromMemory_CBZ(t *testing.T) {
+	var pngBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, tinyImage()); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	w, err := zw.Create("page1.png")
+	if err != nil {
+		t.Fatalf("zip.Create: %v", err)
+	}
+	if _, err := w.Write(pngBuf.Bytes()); err != nil {
+		t.Fatalf("zip write: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip.Close: %v", err)
+	}
+
+	doc, err := NewFromMemory(zipBuf.Bytes())
+	if err != nil {
+		t.Fatalf("NewFromMemory: %v", err)
+	}
+	defer doc.Close()
+
+	if doc.NumPage() != 1 {
+		t.Errorf("NumPage() = %d, want 1", doc.NumPage())
+	}
+}
@@ -0,0 +1,65 @@
+package fitz
+
+/*
+#include <mupdf/fitz.h>
+#include <stdlib.h>
+#include "fitz_shim.h"
+*/
+import "C"
+
+import "image"
+
+// Colorspace selects the pixmap colorspace used when rendering a page.
+type Colorspace int
+
+// Supported colorspaces for ImagePage.
+const (
+	ColorspaceRGB Colorspace = iota
+	ColorspaceGray
+	ColorspaceCMYK
+)
+
+// ImageOptions controls how ImagePage rasterizes a page.
+type ImageOptions struct {
+	// DPI is the rendering resolution, in dots per inch. Defaults to 300.
+	DPI float64
+	// Colorspace selects RGB, Gray or CMYK output. Defaults to RGB.
+	Colorspace Colorspace
+	// Alpha renders with a transparent background instead of opaque white.
+	Alpha bool
+	// Rotation rotates the page clockwise, in degrees, before rendering.
+	Rotation float64
+	// Clip restricts rendering to the given rectangle, in page space. A
+	// nil Clip renders the full page bounds.
+	Clip *image.Rectangle
+	// Antialias sets the anti-aliasing level passed to fz_set_aa_level.
+	// A value of 0 leaves MuPDF's default level untouched.
+	Antialias int
+}
+
+// DefaultImageOptions returns the options used by Image: 300 DPI, RGB,
+// opaque, no rotation.
+func DefaultImageOptions() ImageOptions {
+	return ImageOptions{DPI: 300, Colorspace: ColorspaceRGB}
+}
+
+// ImagePage returns the image for the given page number, rendered with the
+// given options.
+func (f *Document) ImagePage(pageNumber int, opts ImageOptions) (image.Image, error) {
+	if pageNumber >= f.NumPage() {
+		return nil, ErrPageMissing
+	}
+
+	var page *C.struct_fz_page_s
+	if err := checkShim(C.fitz_shim_load_page(f.ctx, f.doc, C.int(pageNumber), &page)); err != nil {
+		return nil, err
+	}
+	defer C.fz_drop_page(f.ctx, page)
+
+	var bounds C.fz_rect
+	C.fz_bound_page(f.ctx, page, &bounds)
+
+	return rasterize(f.ctx, bounds, opts, func(device *C.struct_fz_device_s, ctm *C.fz_matrix) error {
+		return checkShim(C.fitz_shim_run_page(f.ctx, page, device, ctm, nil))
+	})
+}
@@ -0,0 +1,100 @@
+package fitz
+
+/*
+#include <mupdf/fitz.h>
+#include <mupdf/pdf.h>
+#include <stdlib.h>
+#include "fitz_shim.h"
+*/
+import "C"
+
+import "image"
+
+// Link is a clickable region of a page, resolved either to an external URI
+// or, for internal links, to a page number.
+type Link struct {
+	Rect image.Rectangle `json:"rect"`
+	URI  string          `json:"uri"`
+	Page int             `json:"page"`
+}
+
+// Links returns the page's links, with internal destinations resolved to a
+// page number via fz_resolve_link.
+func (f *Document) Links(pageNumber int) ([]Link, error) {
+	if pageNumber >= f.NumPage() {
+		return nil, ErrPageMissing
+	}
+
+	var page *C.struct_fz_page_s
+	if err := checkShim(C.fitz_shim_load_page(f.ctx, f.doc, C.int(pageNumber), &page)); err != nil {
+		return nil, err
+	}
+	defer C.fz_drop_page(f.ctx, page)
+
+	clinks := C.fz_load_links(f.ctx, page)
+	if clinks == nil {
+		return nil, nil
+	}
+	defer C.fz_drop_link(f.ctx, clinks)
+
+	var links []Link
+	for l := clinks; l != nil; l = l.next {
+		link := Link{
+			Rect: image.Rect(int(l.rect.x0), int(l.rect.y0), int(l.rect.x1), int(l.rect.y1)),
+			URI:  C.GoString(l.uri),
+			Page: -1,
+		}
+
+		loc := C.fz_resolve_link(f.doc, l.uri, nil, nil)
+		if loc.page >= 0 {
+			link.Page = int(loc.page)
+		}
+
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+// Annotation is a PDF annotation (highlight, text note, form widget, etc.)
+// found on a page.
+type Annotation struct {
+	Type     string          `json:"type"`
+	Rect     image.Rectangle `json:"rect"`
+	Contents string          `json:"contents"`
+}
+
+// Annotations returns the PDF annotations present on the given page
+// number. Non-PDF documents have no annotations and return an empty slice.
+func (f *Document) Annotations(pageNumber int) ([]Annotation, error) {
+	if pageNumber >= f.NumPage() {
+		return nil, ErrPageMissing
+	}
+
+	pdf := C.pdf_document_from_fz_document(f.ctx, f.doc)
+	if pdf == nil {
+		return nil, nil
+	}
+
+	var page *C.struct_pdf_page_s
+	if err := checkShim(C.fitz_shim_pdf_load_page(f.ctx, pdf, C.int(pageNumber), &page)); err != nil {
+		return nil, err
+	}
+	defer C.pdf_drop_page(f.ctx, page)
+
+	var annots []Annotation
+	for annot := C.pdf_first_annot(f.ctx, page); annot != nil; annot = C.pdf_next_annot(f.ctx, annot) {
+		var bounds C.fz_rect
+		C.pdf_bound_annot(f.ctx, annot, &bounds)
+
+		annotType := C.pdf_annot_type(f.ctx, annot)
+
+		annots = append(annots, Annotation{
+			Type:     C.GoString(C.pdf_string_from_annot_type(f.ctx, annotType)),
+			Rect:     image.Rect(int(bounds.x0), int(bounds.y0), int(bounds.x1), int(bounds.y1)),
+			Contents: C.GoString(C.pdf_annot_contents(f.ctx, annot)),
+		})
+	}
+
+	return annots, nil
+}
@@ -0,0 +1,27 @@
+package fitz
+
+import "testing"
+
+func TestDocument_Links(t *testing.T) {
+	doc := newTestDocument(t)
+
+	links, err := doc.Links(0)
+	if err != nil {
+		t.Fatalf("Links: %v", err)
+	}
+	if len(links) != 0 {
+		t.Errorf("Links() = %v, want empty for a document with no links", links)
+	}
+}
+
+func TestDocument_Annotations(t *testing.T) {
+	doc := newTestDocument(t)
+
+	annots, err := doc.Annotations(0)
+	if err != nil {
+		t.Fatalf("Annotations: %v", err)
+	}
+	if len(annots) != 0 {
+		t.Errorf("Annotations() = %v, want empty for a document with no annotations", annots)
+	}
+}
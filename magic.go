@@ -0,0 +1,49 @@
+package fitz
+
+import "bytes"
+
+// Magic byte signatures used by sniffMimeType to detect a document's
+// format when it isn't known ahead of time, e.g. in NewFromMemory.
+var (
+	magicPDF    = []byte("%PDF")
+	magicPNG    = []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a}
+	magicJPEG   = []byte{0xff, 0xd8, 0xff}
+	magicTIFFLE = []byte{'I', 'I', 0x2a, 0x00}
+	magicTIFFBE = []byte{'M', 'M', 0x00, 0x2a}
+	magicZIP    = []byte{'P', 'K', 0x03, 0x04}
+)
+
+// sniffMimeType inspects the first bytes of b and returns the MIME/magic
+// string to hand to fz_open_document_with_stream. It defaults to
+// "application/pdf" for backward compatibility with callers that pass
+// PDF bytes (the overwhelming common case before this sniffing existed).
+func sniffMimeType(b []byte) string {
+	switch {
+	case bytes.HasPrefix(b, magicPDF):
+		return "application/pdf"
+	case bytes.HasPrefix(b, magicPNG):
+		return "image/png"
+	case bytes.HasPrefix(b, magicJPEG):
+		return "image/jpeg"
+	case bytes.HasPrefix(b, magicTIFFLE), bytes.HasPrefix(b, magicTIFFBE):
+		return "image/tiff"
+	case bytes.HasPrefix(b, magicZIP):
+		return sniffZipMimeType(b)
+	default:
+		return "application/pdf"
+	}
+}
+
+// sniffZipMimeType distinguishes the zip-based formats MuPDF supports
+// (EPUB, XPS, CBZ) by looking for format-identifying strings anywhere in
+// b, falling back to CBZ for plain comic archives.
+func sniffZipMimeType(b []byte) string {
+	switch {
+	case bytes.Contains(b, []byte("application/epub+zip")):
+		return "application/epub+zip"
+	case bytes.Contains(b, []byte("[Content_Types].xml")) && bytes.Contains(b, []byte("FixedDocumentSequence")):
+		return "application/vnd.ms-xpsdocument"
+	default:
+		return "application/x-cbz"
+	}
+}
@@ -0,0 +1,41 @@
+package fitz
+
+import "testing"
+
+func TestSniffMimeType(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"pdf", []byte("%PDF-1.7\n..."), "application/pdf"},
+		{"png", []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a, 0, 0}, "image/png"},
+		{"jpeg", []byte{0xff, 0xd8, 0xff, 0xe0}, "image/jpeg"},
+		{"tiff little-endian", []byte{'I', 'I', 0x2a, 0x00, 0, 0}, "image/tiff"},
+		{"tiff big-endian", []byte{'M', 'M', 0x00, 0x2a, 0, 0}, "image/tiff"},
+		{"cbz", zipBytes("comic/page1.jpg"), "application/x-cbz"},
+		{"epub", zipBytes("mimetype", "application/epub+zip"), "application/epub+zip"},
+		{"xps", zipBytes("[Content_Types].xml", "FixedDocumentSequence"), "application/vnd.ms-xpsdocument"},
+		{"unknown defaults to pdf", []byte("not a real document"), "application/pdf"},
+		{"empty defaults to pdf", nil, "application/pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sniffMimeType(tt.data); got != tt.want {
+				t.Errorf("sniffMimeType(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+// zipBytes builds a fake zip-magic byte slice followed by the given
+// entries, good enough to exercise sniffZipMimeType's bytes.Contains
+// checks without needing a real zip encoder.
+func zipBytes(entries ...string) []byte {
+	b := append([]byte{}, magicZIP...)
+	for _, e := range entries {
+		b = append(b, e...)
+	}
+	return b
+}
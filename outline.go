@@ -0,0 +1,62 @@
+package fitz
+
+/*
+#include <mupdf/fitz.h>
+#include <stdlib.h>
+#include "fitz_shim.h"
+*/
+import "C"
+
+// OutlineItem is a single entry in a document's table of contents, as
+// returned by Outline.
+type OutlineItem struct {
+	Title    string        `json:"title"`
+	Page     int           `json:"page"`
+	URI      string        `json:"uri"`
+	Children []OutlineItem `json:"children,omitempty"`
+}
+
+// Outline returns the document's table of contents, if it has one. An
+// empty slice is returned for documents without an outline.
+func (f *Document) Outline() ([]OutlineItem, error) {
+	var outline *C.fz_outline
+	if err := checkShim(C.fitz_shim_load_outline(f.ctx, f.doc, &outline)); err != nil {
+		return nil, err
+	}
+	if outline == nil {
+		return nil, nil
+	}
+	defer C.fz_drop_outline(f.ctx, outline)
+
+	return f.walkOutline(outline), nil
+}
+
+// walkOutline recursively converts a fz_outline linked list into
+// OutlineItems, following both down (children) and next (siblings).
+func (f *Document) walkOutline(outline *C.fz_outline) []OutlineItem {
+	var items []OutlineItem
+
+	for o := outline; o != nil; o = o.next {
+		item := OutlineItem{
+			Title: C.GoString(o.title),
+			Page:  -1,
+		}
+
+		if o.uri != nil {
+			item.URI = C.GoString(o.uri)
+
+			loc := C.fz_resolve_link(f.doc, o.uri, nil, nil)
+			if loc.page >= 0 {
+				item.Page = int(loc.page)
+			}
+		}
+
+		if o.down != nil {
+			item.Children = f.walkOutline(o.down)
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
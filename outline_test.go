@@ -0,0 +1,15 @@
+package fitz
+
+import "testing"
+
+func TestDocument_Outline(t *testing.T) {
+	doc := newTestDocument(t)
+
+	items, err := doc.Outline()
+	if err != nil {
+		t.Fatalf("Outline: %v", err)
+	}
+	if len(items) != 0 {
+		t.Errorf("Outline() = %v, want empty for a document with no table of contents", items)
+	}
+}
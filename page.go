@@ -0,0 +1,117 @@
+package fitz
+
+/*
+#include <mupdf/fitz.h>
+#include <stdlib.h>
+#include "fitz_shim.h"
+*/
+import "C"
+
+import (
+	"image"
+	"math"
+	"runtime"
+)
+
+// CachedPage is a handle to a single document page whose content has been
+// parsed once into a cached fz_display_list. Unlike ImagePage, which re-runs
+// the page's content stream on every call, a CachedPage can be rendered
+// repeatedly, and concurrently from multiple goroutines, via Render and
+// Thumbnail.
+type CachedPage struct {
+	doc    *Document
+	number int
+	list   *C.fz_display_list
+	bounds C.fz_rect
+}
+
+// LoadPage parses the given page number once into a cached display list.
+func (f *Document) LoadPage(pageNumber int) (*CachedPage, error) {
+	if pageNumber >= f.NumPage() {
+		return nil, ErrPageMissing
+	}
+
+	var cpage *C.struct_fz_page_s
+	if err := checkShim(C.fitz_shim_load_page(f.ctx, f.doc, C.int(pageNumber), &cpage)); err != nil {
+		return nil, err
+	}
+	defer C.fz_drop_page(f.ctx, cpage)
+
+	var bounds C.fz_rect
+	C.fz_bound_page(f.ctx, cpage, &bounds)
+
+	list := C.fz_new_display_list(f.ctx, bounds)
+
+	device := C.fz_new_list_device(f.ctx, list)
+	ctm := C.fz_identity
+	if err := checkShim(C.fitz_shim_run_page(f.ctx, cpage, device, &ctm, nil)); err != nil {
+		C.fz_close_device(f.ctx, device)
+		C.fz_drop_device(f.ctx, device)
+		C.fz_drop_display_list(f.ctx, list)
+		return nil, err
+	}
+	C.fz_close_device(f.ctx, device)
+	C.fz_drop_device(f.ctx, device)
+
+	return &CachedPage{doc: f, number: pageNumber, list: list, bounds: bounds}, nil
+}
+
+// Close releases the page's cached display list.
+func (p *CachedPage) Close() error {
+	C.fz_drop_display_list(p.doc.ctx, p.list)
+	return nil
+}
+
+// Render rasterizes the cached display list with the given options. It may
+// be called concurrently from multiple goroutines, including concurrently
+// with other CachedPages of the same Document: each call borrows a context
+// cloned from the document's context via fz_clone_context, since a single
+// fz_context is not safe to share across goroutines.
+func (p *CachedPage) Render(opts ImageOptions) (image.Image, error) {
+	ctx := p.doc.borrowContext()
+	defer p.doc.releaseContext(ctx)
+
+	return rasterize(ctx, p.bounds, opts, func(device *C.struct_fz_device_s, ctm *C.fz_matrix) error {
+		C.fz_run_display_list(ctx, p.list, device, *ctm, &C.fz_infinite_rect, nil)
+		return nil
+	})
+}
+
+// Thumbnail renders the cached display list scaled so that its longest
+// side is at most maxPx pixels.
+func (p *CachedPage) Thumbnail(maxPx int) (image.Image, error) {
+	w := float64(p.bounds.x1 - p.bounds.x0)
+	h := float64(p.bounds.y1 - p.bounds.y0)
+
+	scale := float64(maxPx) / math.Max(w, h)
+
+	return p.Render(ImageOptions{DPI: 72 * scale, Colorspace: ColorspaceRGB})
+}
+
+// borrowContext takes a cloned context from the document's context pool,
+// creating the pool on first use sized to GOMAXPROCS. It registers the
+// borrow with f.ctxWG so Close can wait for every outstanding borrow to be
+// released before it drops the pool's contexts out from under them.
+func (f *Document) borrowContext() *C.struct_fz_context_s {
+	f.poolOnce.Do(func() {
+		n := runtime.GOMAXPROCS(0)
+		if n < 1 {
+			n = 1
+		}
+
+		f.ctxPool = make(chan *C.struct_fz_context_s, n)
+		for i := 0; i < n; i++ {
+			f.ctxPool <- C.fz_clone_context(f.ctx)
+		}
+	})
+
+	f.ctxWG.Add(1)
+
+	return <-f.ctxPool
+}
+
+// releaseContext returns a context borrowed via borrowContext to the pool.
+func (f *Document) releaseContext(ctx *C.struct_fz_context_s) {
+	f.ctxPool <- ctx
+	f.ctxWG.Done()
+}
@@ -0,0 +1,72 @@
+package fitz
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCachedPage_RenderConcurrent(t *testing.T) {
+	doc := newTestDocument(t)
+
+	page, err := doc.LoadPage(0)
+	if err != nil {
+		t.Fatalf("LoadPage: %v", err)
+	}
+	defer page.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := page.Render(DefaultImageOptions()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCachedPage_Thumbnail(t *testing.T) {
+	doc := newTestDocument(t)
+
+	page, err := doc.LoadPage(0)
+	if err != nil {
+		t.Fatalf("LoadPage: %v", err)
+	}
+	defer page.Close()
+
+	img, err := page.Thumbnail(64)
+	if err != nil {
+		t.Fatalf("Thumbnail: %v", err)
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() > 64 || bounds.Dy() > 64 {
+		t.Errorf("Thumbnail bounds %v exceed maxPx 64", bounds)
+	}
+}
+
+// TestDocument_CloseWaitsForRender guards against Close racing a concurrent
+// Render: closing the context pool out from under a borrower used to panic.
+func TestDocument_CloseWaitsForRender(t *testing.T) {
+	doc, err := NewFromMemory([]byte(testPDF))
+	if err != nil {
+		t.Fatalf("NewFromMemory: %v", err)
+	}
+
+	page, err := doc.LoadPage(0)
+	if err != nil {
+		t.Fatalf("LoadPage: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		page.Render(DefaultImageOptions())
+	}()
+
+	doc.Close()
+	wg.Wait()
+}
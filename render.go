@@ -0,0 +1,132 @@
+package fitz
+
+/*
+#include <mupdf/fitz.h>
+#include <stdlib.h>
+#include "fitz_shim.h"
+*/
+import "C"
+
+import (
+	"image"
+	"unsafe"
+)
+
+// rasterize allocates a pixmap sized for bounds under opts, invokes draw to
+// paint it (via fz_run_page or fz_run_display_list), and decodes the result
+// into a Go image. It is shared by ImagePage and CachedPage.Render so the pixmap
+// layout and colorspace decoding only need to be gotten right once.
+//
+// The pixmap is always allocated with an alpha channel, regardless of
+// opts.Alpha, so every colorspace has one fixed, predictable stride:
+// opts.Alpha only chooses the pixmap's initial clear color (transparent vs.
+// opaque white), not its byte layout. The extra alpha byte is dropped when
+// decoding into Gray/CMYK, whose Go image types carry no alpha channel; for
+// RGB it lines up with image.RGBA's own channel count, so no decode-time
+// copy is needed there.
+func rasterize(ctx *C.struct_fz_context_s, bounds C.fz_rect, opts ImageOptions, draw func(device *C.struct_fz_device_s, ctm *C.fz_matrix) error) (image.Image, error) {
+	if opts.Clip != nil {
+		bounds.x0 = C.float(opts.Clip.Min.X)
+		bounds.y0 = C.float(opts.Clip.Min.Y)
+		bounds.x1 = C.float(opts.Clip.Max.X)
+		bounds.y1 = C.float(opts.Clip.Max.Y)
+	}
+
+	if opts.Antialias > 0 {
+		C.fz_set_aa_level(ctx, C.int(opts.Antialias))
+	}
+
+	dpi := opts.DPI
+	if dpi == 0 {
+		dpi = 300
+	}
+
+	var ctm C.fz_matrix
+	C.fz_scale(&ctm, C.float(dpi/72), C.float(dpi/72))
+
+	if opts.Rotation != 0 {
+		var rotate C.fz_matrix
+		C.fz_rotate(&rotate, C.float(opts.Rotation))
+		C.fz_concat(&ctm, &ctm, &rotate)
+	}
+
+	var bbox C.fz_irect
+	C.fz_transform_rect(&bounds, &ctm)
+	C.fz_round_rect(&bbox, &bounds)
+
+	colorspace := C.fz_device_rgb(ctx)
+	channels := 3
+	switch opts.Colorspace {
+	case ColorspaceGray:
+		colorspace = C.fz_device_gray(ctx)
+		channels = 1
+	case ColorspaceCMYK:
+		colorspace = C.fz_device_cmyk(ctx)
+		channels = 4
+	}
+
+	pixmap := C.fz_new_pixmap_with_bbox(ctx, colorspace, &bbox, nil, C.int(1))
+	if pixmap == nil {
+		return nil, ErrCreatePixmap
+	}
+	defer C.fz_drop_pixmap(ctx, pixmap)
+
+	if opts.Alpha {
+		C.fz_clear_pixmap(ctx, pixmap)
+	} else {
+		C.fz_clear_pixmap_with_value(ctx, pixmap, C.int(0xff))
+	}
+
+	device := C.fz_new_draw_device(ctx, &ctm, pixmap)
+	defer C.fz_drop_device(ctx, device)
+
+	// The draw device already applies ctm, so the page or display list is
+	// run with the identity matrix to avoid scaling its content twice.
+	identity := C.fz_identity
+	if err := draw(device, &identity); err != nil {
+		return nil, err
+	}
+	C.fz_close_device(ctx, device)
+
+	pixels := C.fz_pixmap_samples(ctx, pixmap)
+	if pixels == nil {
+		return nil, ErrPixmapSamples
+	}
+
+	n := int(C.fz_pixmap_components(ctx, pixmap))
+	w, h := int(bbox.x1-bbox.x0), int(bbox.y1-bbox.y0)
+	data := C.GoBytes(unsafe.Pointer(pixels), C.int(n*w*h))
+	rect := image.Rect(int(bbox.x0), int(bbox.y0), int(bbox.x1), int(bbox.y1))
+
+	switch opts.Colorspace {
+	case ColorspaceGray:
+		return &image.Gray{Pix: packChannels(data, n, channels), Stride: rect.Dx(), Rect: rect}, nil
+	case ColorspaceCMYK:
+		return cmykImage(packChannels(data, n, channels), rect), nil
+	default:
+		return &image.RGBA{Pix: data, Stride: 4 * rect.Dx(), Rect: rect}, nil
+	}
+}
+
+// packChannels strips MuPDF's trailing alpha byte from each n-byte pixel,
+// returning a tight channels-byte-per-pixel buffer. It is a no-op (and
+// copy-free) when n already equals channels.
+func packChannels(data []byte, n, channels int) []byte {
+	if n == channels {
+		return data
+	}
+
+	packed := make([]byte, 0, (len(data)/n)*channels)
+	for i := 0; i+n <= len(data); i += n {
+		packed = append(packed, data[i:i+channels]...)
+	}
+
+	return packed
+}
+
+// cmykImage converts packed CMYK pixmap samples into an image.CMYK.
+func cmykImage(data []byte, rect image.Rectangle) *image.CMYK {
+	img := image.NewCMYK(rect)
+	copy(img.Pix, data)
+	return img
+}
@@ -0,0 +1,10 @@
+package fitz
+
+import "testing"
+
+func TestNewFromMemory_MalformedDocument(t *testing.T) {
+	_, err := NewFromMemory([]byte("this is not a document"))
+	if err == nil {
+		t.Fatal("expected an error opening malformed data, got nil")
+	}
+}
@@ -0,0 +1,245 @@
+package fitz
+
+/*
+#include <mupdf/fitz.h>
+#include <stdlib.h>
+#include "fitz_shim.h"
+*/
+import "C"
+
+import (
+	"bytes"
+	"encoding/json"
+	"html"
+	"strconv"
+)
+
+// Rect is a float bounding box, mirroring fz_rect.
+type Rect struct {
+	X0 float64 `json:"x0"`
+	Y0 float64 `json:"y0"`
+	X1 float64 `json:"x1"`
+	Y1 float64 `json:"y1"`
+}
+
+// Span is a run of text sharing the same font and size within a Line.
+type Span struct {
+	Text   string  `json:"text"`
+	Font   string  `json:"font"`
+	Size   float64 `json:"size"`
+	Bounds Rect    `json:"bounds"`
+}
+
+// Line is a line of text made up of one or more Spans.
+type Line struct {
+	Bounds    Rect       `json:"bounds"`
+	WMode     int        `json:"wmode"`
+	Direction [2]float64 `json:"direction"`
+	Spans     []Span     `json:"spans"`
+}
+
+// Block is a block of text made up of one or more Lines.
+type Block struct {
+	Bounds Rect   `json:"bounds"`
+	Lines  []Line `json:"lines"`
+}
+
+// Page is the structured text content of a single page, as produced by
+// StructuredText.
+type Page struct {
+	Number int     `json:"number"`
+	Bounds Rect    `json:"bounds"`
+	Blocks []Block `json:"blocks"`
+}
+
+// Text returns the plain text content of the given page number.
+func (f *Document) Text(pageNumber int) (string, error) {
+	page, err := f.StructuredText(pageNumber)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	for _, block := range page.Blocks {
+		for _, line := range block.Lines {
+			for _, span := range line.Spans {
+				buf.WriteString(span.Text)
+			}
+			buf.WriteString("\n")
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// StructuredText returns the blocks, lines and spans of the given page
+// number, including bounding boxes, font metadata and reading direction.
+func (f *Document) StructuredText(pageNumber int) (*Page, error) {
+	if pageNumber >= f.NumPage() {
+		return nil, ErrPageMissing
+	}
+
+	var cpage *C.struct_fz_page_s
+	if err := checkShim(C.fitz_shim_load_page(f.ctx, f.doc, C.int(pageNumber), &cpage)); err != nil {
+		return nil, err
+	}
+	defer C.fz_drop_page(f.ctx, cpage)
+
+	var bounds C.fz_rect
+	C.fz_bound_page(f.ctx, cpage, &bounds)
+
+	stext := C.fz_new_stext_page(f.ctx, bounds)
+	defer C.fz_drop_stext_page(f.ctx, stext)
+
+	opts := C.fz_stext_options{}
+	device := C.fz_new_stext_device(f.ctx, stext, &opts)
+	defer C.fz_drop_device(f.ctx, device)
+
+	ctm := C.fz_identity
+	if err := checkShim(C.fitz_shim_run_page(f.ctx, cpage, device, &ctm, nil)); err != nil {
+		return nil, err
+	}
+	C.fz_close_device(f.ctx, device)
+
+	page := &Page{
+		Number: pageNumber,
+		Bounds: rectFromC(bounds),
+	}
+
+	for block := stext.first_block; block != nil; block = block.next {
+		if block._type != C.FZ_STEXT_BLOCK_TEXT {
+			continue
+		}
+
+		page.Blocks = append(page.Blocks, textBlock(f.ctx, block))
+	}
+
+	return page, nil
+}
+
+// textBlock walks a single fz_stext_block's lines and chars into a Block.
+func textBlock(ctx *C.struct_fz_context_s, block *C.fz_stext_block) Block {
+	b := Block{Bounds: rectFromC(block.bbox)}
+
+	for line := block.u.t.first_line; line != nil; line = line.next {
+		l := Line{
+			Bounds:    rectFromC(line.bbox),
+			WMode:     int(line.wmode),
+			Direction: [2]float64{float64(line.dir.x), float64(line.dir.y)},
+		}
+
+		var (
+			runes  []rune
+			font   string
+			size   float64
+			sbound Rect
+			dirty  bool
+		)
+
+		flush := func() {
+			if dirty {
+				l.Spans = append(l.Spans, Span{Text: string(runes), Font: font, Size: size, Bounds: sbound})
+			}
+			runes = nil
+			dirty = false
+		}
+
+		var curFont *C.fz_font
+		for char := line.first_char; char != nil; char = char.next {
+			if char.font != curFont || float64(char.size) != size {
+				flush()
+				curFont = char.font
+				font = C.GoString(C.fz_font_name(ctx, char.font))
+				size = float64(char.size)
+				sbound = Rect{}
+			}
+
+			charBounds := rectFromQuad(char.quad)
+			if !dirty {
+				sbound = charBounds
+			} else {
+				sbound = unionRect(sbound, charBounds)
+			}
+			runes = append(runes, rune(char.c))
+			dirty = true
+		}
+		flush()
+
+		b.Lines = append(b.Lines, l)
+	}
+
+	return b
+}
+
+// TextHTML renders the page's structured text as a minimal HTML fragment,
+// positioning each span absolutely by its bounding box.
+func (f *Document) TextHTML(pageNumber int) (string, error) {
+	page, err := f.StructuredText(pageNumber)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("<div class=\"page\">\n")
+	for _, block := range page.Blocks {
+		for _, line := range block.Lines {
+			for _, span := range line.Spans {
+				buf.WriteString("<span style=\"position:absolute;left:")
+				buf.WriteString(strconv.FormatFloat(span.Bounds.X0, 'f', 2, 64))
+				buf.WriteString("px;top:")
+				buf.WriteString(strconv.FormatFloat(span.Bounds.Y0, 'f', 2, 64))
+				buf.WriteString("px;font-size:")
+				buf.WriteString(strconv.FormatFloat(span.Size, 'f', 2, 64))
+				buf.WriteString("px;\">")
+				buf.WriteString(html.EscapeString(span.Text))
+				buf.WriteString("</span>\n")
+			}
+		}
+	}
+	buf.WriteString("</div>\n")
+
+	return buf.String(), nil
+}
+
+// TextJSON returns the page's structured text serialized as JSON, suitable
+// for OCR-overlay or search indexing use cases.
+func (f *Document) TextJSON(pageNumber int) ([]byte, error) {
+	page, err := f.StructuredText(pageNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(page)
+}
+
+func rectFromC(r C.fz_rect) Rect {
+	return Rect{X0: float64(r.x0), Y0: float64(r.y0), X1: float64(r.x1), Y1: float64(r.y1)}
+}
+
+func rectFromQuad(q C.fz_quad) Rect {
+	r := C.fz_rect_from_quad(q)
+	return rectFromC(r)
+}
+
+func unionRect(a, b Rect) Rect {
+	return Rect{
+		X0: minFloat(a.X0, b.X0),
+		Y0: minFloat(a.Y0, b.Y0),
+		X1: maxFloat(a.X1, b.X1),
+		Y1: maxFloat(a.Y1, b.Y1),
+	}
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
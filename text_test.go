@@ -0,0 +1,54 @@
+package fitz
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDocument_Text(t *testing.T) {
+	doc := newTestDocument(t)
+
+	text, err := doc.Text(0)
+	if err != nil {
+		t.Fatalf("Text: %v", err)
+	}
+	if !strings.Contains(text, "Hello") {
+		t.Errorf("Text() = %q, want it to contain %q", text, "Hello")
+	}
+}
+
+func TestDocument_StructuredText(t *testing.T) {
+	doc := newTestDocument(t)
+
+	page, err := doc.StructuredText(0)
+	if err != nil {
+		t.Fatalf("StructuredText: %v", err)
+	}
+	if len(page.Blocks) == 0 {
+		t.Fatal("StructuredText returned no blocks")
+	}
+}
+
+func TestDocument_TextJSON(t *testing.T) {
+	doc := newTestDocument(t)
+
+	b, err := doc.TextJSON(0)
+	if err != nil {
+		t.Fatalf("TextJSON: %v", err)
+	}
+	if len(b) == 0 {
+		t.Fatal("TextJSON returned no bytes")
+	}
+}
+
+func TestDocument_TextHTML(t *testing.T) {
+	doc := newTestDocument(t)
+
+	html, err := doc.TextHTML(0)
+	if err != nil {
+		t.Fatalf("TextHTML: %v", err)
+	}
+	if !strings.Contains(html, "Hello") {
+		t.Errorf("TextHTML() = %q, want it to contain %q", html, "Hello")
+	}
+}